@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+	"github.com/percona/percona-backup-mongodb/pbm/lock"
+	"github.com/percona/percona-backup-mongodb/pbm/log"
+	"github.com/percona/percona-backup-mongodb/pbm/topo"
+)
+
+// Agent is a single pbm-agent process: one per mongod node, talking to its
+// own node (nodeConn) and to the cluster's config server (leadConn).
+type Agent struct {
+	leadConn connect.Client
+	nodeConn *mongo.Client
+	brief    topo.NodeBrief
+
+	mx      sync.Mutex
+	pitrjob *currentPitr
+	prevOO  *bool
+
+	// pitrFailCount is the number of consecutive PITR stream failures,
+	// guarded by mx alongside pitrjob/prevOO. It drives the backoff delay
+	// between retries and resets on a successful stream cycle.
+	pitrFailCount int
+}
+
+// acquireLock tries to acquire lck, logging and swallowing a concurrent-op
+// conflict as "not acquired" rather than an error, since that's an expected
+// outcome of racing other nodes for the same lock.
+func (a *Agent) acquireLock(ctx context.Context, lck *lock.OpLock, l *log.Event) (bool, error) {
+	got, err := lck.Acquire(ctx)
+	if err != nil {
+		var concurrent lock.ConcurrentOpError
+		if errors.As(err, &concurrent) {
+			l.Debug("acquire lock: %v", concurrent)
+			return false, nil
+		}
+		return false, err
+	}
+
+	return got, nil
+}
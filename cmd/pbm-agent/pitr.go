@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"math/rand"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -25,6 +26,17 @@ type currentPitr struct {
 	slicer *slicer.Slicer
 	w      chan ctrl.OPID // to wake up a slicer on demand (not to wait for the tick)
 	cancel context.CancelFunc
+	// hardCancel aborts the in-progress Stream call outright, skipping the
+	// graceful span-flush that a plain cancel() allows for.
+	hardCancel context.CancelFunc
+	done       chan struct{} // closed once the slicing goroutine has returned
+
+	// lease and stopLeaseRenew let shutdownPITR reclaim the PITR lease itself
+	// once it's given up on Stream ever returning, instead of leaving the
+	// stuck goroutine's renewPITRLease renewing a lease for an owner that's
+	// being force-evicted.
+	lease          *lock.Lease
+	stopLeaseRenew context.CancelFunc
 }
 
 func (a *Agent) setPitr(p *currentPitr) {
@@ -62,14 +74,38 @@ func (a *Agent) sliceNow(opid ctrl.OPID) {
 
 const (
 	pitrCheckPeriod              = 15 * time.Second
-	pitrRenominationFrame        = 5 * time.Second
 	pitrOpLockPollingCycle       = 15 * time.Second
 	pitrOpLockPollingTimeOut     = 2 * time.Minute
 	pitrNominationPollingCycle   = 2 * time.Second
 	pitrNominationPollingTimeOut = 2 * time.Minute
+
+	// pitrDefaultShutdownGrace is used when Config.PITR.ShutdownGrace is unset.
+	pitrDefaultShutdownGrace = 30 * time.Second
+	// pitrShutdownHardKill bounds how long we wait for the slicer to react to a
+	// forced cancellation before we give up on it and release the lock anyway.
+	pitrShutdownHardKill = 10 * time.Second
+	// pitrShutdownConfigTimeout bounds the config read shutdownPITR does to
+	// look up Config.PITR.ShutdownGrace, so an unhealthy mongo/storage
+	// connection - the exact scenario the grace/hard-kill sequence exists for
+	// - can't also stall shutdown from even starting to drain the slicer.
+	pitrShutdownConfigTimeout = 5 * time.Second
+
+	// pitrNominationLeaseTTL is the TTL granted to the PITR lease held by the
+	// winning nominee. It's renewed every pitrNominationLeaseTTL/3 by the
+	// owner; nominateRSForPITR also waits pitrNominationLeaseTTL/3 between
+	// candidate batches.
+	pitrNominationLeaseTTL = 30 * time.Second
+
+	// pitrBackoffBase and pitrBackoffCap bound the exponential retry delay
+	// applied after a failed stream cycle: min(base*2^n, cap) + rand(0, base).
+	pitrBackoffBase = pitrCheckPeriod
+	pitrBackoffCap  = 5 * time.Minute
 )
 
-// PITR starts PITR processing routine
+// PITR starts PITR processing routine. It keeps slicing the oplog until ctx
+// is done, at which point it stops nominating/accepting new work and gives
+// the in-flight slice up to Config.PITR.ShutdownGrace to flush before
+// returning.
 func (a *Agent) PITR(ctx context.Context) {
 	l := log.FromContext(ctx)
 	l.Printf("starting PITR routine")
@@ -83,7 +119,150 @@ func (a *Agent) PITR(ctx context.Context) {
 			l.Error(string(ctrl.CmdPITR), "", "", ep.TS(), "init: %v", err)
 		}
 
-		time.Sleep(pitrCheckPeriod)
+		select {
+		case <-ctx.Done():
+			// ctx is already done, so get a fresh epoch for the shutdown
+			// event the same way the error branch above does.
+			ep, _ := config.GetEpoch(context.Background(), a.leadConn)
+			a.shutdownPITR(l.NewEvent(string(ctrl.CmdPITR), "", "", ep.TS()))
+			l.Printf("pitr routine stopped")
+			return
+		case <-time.After(pitrCheckPeriod):
+		}
+	}
+}
+
+// shutdownPITR drains the currently running slicer, if any, giving it up to
+// Config.PITR.ShutdownGrace to finish its current span and flush it to
+// storage. Past the grace period the slicer is force-cancelled and, if it
+// still hasn't wound down after pitrShutdownHardKill, the OpLock is released
+// with a "shutdown-forced" marker so peers don't have to wait out
+// defs.StaleFrameSec to renominate.
+func (a *Agent) shutdownPITR(l *log.Event) {
+	p := a.getPitr()
+	if p == nil {
+		return
+	}
+
+	grace := pitrDefaultShutdownGrace
+	// a fresh, bounded context: the one PITR() was given is already done, but
+	// we still need to talk to the lead connection during shutdown - bounded
+	// because an unhealthy connection is exactly the scenario this grace
+	// period exists for, and it shouldn't be able to stall the drain from
+	// even starting.
+	cfgCtx, cfgCancel := context.WithTimeout(context.Background(), pitrShutdownConfigTimeout)
+	cfg, err := config.GetConfig(cfgCtx, a.leadConn)
+	cfgCancel()
+	if err == nil {
+		if cfg.PITR.ShutdownGrace > 0 {
+			grace = cfg.PITR.ShutdownGrace
+		}
+	} else {
+		l.Warning("shutdown: get config for shutdown grace: %v", err)
+	}
+
+	// stop accepting new nominations and ask the running slicer to wind down:
+	// finish the span it's currently building and flush it to storage.
+	p.cancel()
+
+	select {
+	case <-p.done:
+		return
+	case <-time.After(grace):
+		l.Warning("pitr slicer did not finish within %s, forcing cancel", grace)
+	}
+
+	p.hardCancel()
+
+	select {
+	case <-p.done:
+	case <-time.After(pitrShutdownHardKill):
+		l.Error("pitr slicer did not stop within %s of force-cancel, releasing lock as shutdown-forced", pitrShutdownHardKill)
+		if err := lock.ReleaseStale(context.Background(), a.leadConn, lock.LockHeader{
+			Replset: a.brief.SetName,
+			Node:    a.brief.Me,
+			Type:    ctrl.CmdPITR,
+		}, "shutdown-forced"); err != nil {
+			l.Error("shutdown: force-release pitr lock: %v", err)
+		}
+	}
+
+	// Either way, the goroutine that owns renewPITRLease may be stuck inside
+	// Stream and never reach its own cleanup: stop renewing (idempotent if it
+	// already did) and drop the lease ourselves so peers don't keep waiting
+	// out its TTL for an owner that's being force-evicted.
+	if p.stopLeaseRenew != nil {
+		p.stopLeaseRenew()
+	}
+	if p.lease != nil {
+		if err := p.lease.Revoke(context.Background()); err != nil {
+			l.Error("shutdown: revoke pitr lease: %v", err)
+		}
+	}
+}
+
+// renewPITRLease keeps the winning nominee's lease alive for as long as it
+// holds the PITR OpLock, renewing it every TTL/3. Once ctx is done (the lock
+// is about to be released) it stops renewing; the caller then revokes the
+// lease.
+func (a *Agent) renewPITRLease(ctx context.Context, lease *lock.Lease, l *log.Event) {
+	tk := time.NewTicker(pitrNominationLeaseTTL / 3)
+	defer tk.Stop()
+
+	for {
+		select {
+		case <-tk.C:
+			if err := lease.KeepAlive(ctx); err != nil {
+				l.Error("renew pitr lease: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pitrBackoffDelay computes the retry delay after the n'th consecutive
+// stream failure: min(base * 2^n, cap) + rand(0, base). This mirrors the
+// pace/backoff pattern used by scanner loops in other distributed stores.
+func pitrBackoffDelay(n int) time.Duration {
+	d := pitrBackoffCap
+	if shift := uint(n); shift < 32 {
+		if scaled := pitrBackoffBase * time.Duration(1<<shift); scaled > 0 && scaled < pitrBackoffCap {
+			d = scaled
+		}
+	}
+
+	return d + time.Duration(rand.Int63n(int64(pitrBackoffBase)))
+}
+
+// pitrBackoff returns how long the agent should wait before its next PITR
+// attempt after a stream failure, advancing the failure counter and
+// persisting the new backoff state to the agent's status document so
+// `pbm status` can surface "node X in backoff (attempt N, next try in Ys)".
+func (a *Agent) pitrBackoff(ctx context.Context, l *log.Event) time.Duration {
+	a.mx.Lock()
+	n := a.pitrFailCount
+	a.pitrFailCount++
+	a.mx.Unlock()
+
+	d := pitrBackoffDelay(n)
+
+	if err := topo.SetAgentPITRBackoff(ctx, a.leadConn, a.brief.SetName, a.brief.Me, n+1, d); err != nil {
+		l.Error("persist pitr backoff status: %v", err)
+	}
+
+	return d
+}
+
+// pitrBackoffReset clears the failure counter after a successful stream
+// cycle, so the next failure starts the backoff over from attempt 0.
+func (a *Agent) pitrBackoffReset(ctx context.Context, l *log.Event) {
+	a.mx.Lock()
+	a.pitrFailCount = 0
+	a.mx.Unlock()
+
+	if err := topo.ClearAgentPITRBackoff(ctx, a.leadConn, a.brief.SetName, a.brief.Me); err != nil {
+		l.Error("clear pitr backoff status: %v", err)
 	}
 }
 
@@ -102,7 +281,11 @@ func (a *Agent) stopPitrOnOplogOnlyChange(currOO bool) {
 }
 
 // canSlicingNow returns lock.ConcurrentOpError if there is a parallel operation.
-// Only physical backups (full, incremental, external) is allowed.
+// Only physical backups (full, incremental, external) is allowed. A PITR
+// lock whose lease has already expired doesn't count: its owner is gone, so
+// it no longer blocks slicing. Other lock kinds don't carry a lease yet, so
+// they keep blocking unconditionally until their acquisition is migrated
+// too.
 func canSlicingNow(ctx context.Context, conn connect.Client) error {
 	locks, err := lock.GetLocks(ctx, conn, &lock.LockHeader{})
 	if err != nil {
@@ -112,8 +295,22 @@ func canSlicingNow(ctx context.Context, conn connect.Client) error {
 	for i := range locks {
 		l := &locks[i]
 
+		// only PITR locks are granted a lease so far; backup/restore/resync
+		// still go through the plain lock.Acquire path and carry no LeaseID,
+		// so they must keep blocking unconditionally rather than reading as
+		// "expired".
+		if l.Type == ctrl.CmdPITR {
+			live, err := lock.IsLeaseLive(ctx, conn, l.LeaseID)
+			if err != nil {
+				return errors.Wrap(err, "check lock lease")
+			}
+			if !live {
+				continue
+			}
+		}
+
 		if l.Type != ctrl.CmdBackup {
-			return lock.ConcurrentOpError{l.LockHeader}
+			return lock.ConcurrentOpError{Lock: l.LockHeader}
 		}
 
 		bcp, err := backup.GetBackupByOPID(ctx, conn, l.OPID)
@@ -122,7 +319,7 @@ func canSlicingNow(ctx context.Context, conn connect.Client) error {
 		}
 
 		if bcp.Type == defs.LogicalBackup {
-			return lock.ConcurrentOpError{l.LockHeader}
+			return lock.ConcurrentOpError{Lock: l.LockHeader}
 		}
 	}
 
@@ -173,6 +370,7 @@ func (a *Agent) pitr(ctx context.Context) error {
 				a.sliceNow(ctrl.NilOPID)
 			}
 		}
+		p.slicer.SetBackoff(cfg.PITR.MaxOplogSlicerInterval)
 
 		return nil
 	}
@@ -278,7 +476,25 @@ func (a *Agent) pitr(ctx context.Context) error {
 		l.Debug("skip: lock not acquired")
 		return nil
 	}
-	err = oplog.SetPITRNomineeACK(ctx, a.leadConn, a.brief.SetName, a.brief.Me)
+
+	lease, err := lock.GrantLease(ctx, a.leadConn, pitrNominationLeaseTTL)
+	if err != nil {
+		if err := lck.Release(); err != nil {
+			l.Error("release lock: %v", err)
+		}
+		return errors.Wrap(err, "grant pitr lease")
+	}
+
+	err = oplog.SetPITRNomineeACK(ctx, a.leadConn, a.brief.SetName, a.brief.Me, lease.ID())
+	if err != nil {
+		if err := lease.Revoke(ctx); err != nil {
+			l.Error("revoke pitr lease: %v", err)
+		}
+		if err := lck.Release(); err != nil {
+			l.Error("release lock: %v", err)
+		}
+		return errors.Wrap(err, "ack pitr nomination")
+	}
 
 	stg, err := util.StorageFromConfig(cfg.Storage, l)
 	if err != nil {
@@ -287,6 +503,10 @@ func (a *Agent) pitr(ctx context.Context) error {
 
 	s := slicer.NewSlicer(a.brief.SetName, a.leadConn, a.nodeConn, stg, cfg, log.FromContext(ctx))
 	s.SetSpan(slicerInterval)
+	// let the slicer grow the span on its own, geometrically, up to the
+	// configured ceiling when the oplog write rate is low, so idle clusters
+	// don't churn tiny chunks every pitrCheckPeriod.
+	s.SetBackoff(cfg.PITR.MaxOplogSlicerInterval)
 
 	if cfg.PITR.OplogOnly {
 		err = s.OplogOnlyCatchup(ctx)
@@ -294,6 +514,9 @@ func (a *Agent) pitr(ctx context.Context) error {
 		err = s.Catchup(ctx)
 	}
 	if err != nil {
+		if err := lease.Revoke(ctx); err != nil {
+			l.Error("revoke pitr lease: %v", err)
+		}
 		if err := lck.Release(); err != nil {
 			l.Error("release lock: %v", err)
 		}
@@ -301,15 +524,34 @@ func (a *Agent) pitr(ctx context.Context) error {
 	}
 
 	go func() {
-		stopSlicingCtx, stopSlicing := context.WithCancel(ctx)
+		// decoupled from ctx on purpose: ctx is the agent's shutdown context,
+		// and we want shutdownPITR to control exactly when Stream gets cut
+		// off, rather than having it die the instant the agent starts exiting.
+		hardCtx, hardCancel := context.WithCancel(context.Background())
+		defer hardCancel()
+
+		// also decoupled from ctx: shutdownPITR calls cancel itself once it
+		// has picked this job up, so the job must still be registered then.
+		stopSlicingCtx, stopSlicing := context.WithCancel(context.Background())
 		defer stopSlicing()
 		stopC := make(chan struct{})
+		// done is closed once the lock is released, not once this whole
+		// goroutine returns: shutdownPITR only needs to know the slicer has
+		// wound down and handed the lock back, not that the (possibly
+		// multi-minute) retry-penalty sleep below has also finished.
+		done := make(chan struct{})
+
+		leaseRenewCtx, stopLeaseRenew := context.WithCancel(context.Background())
 
 		w := make(chan ctrl.OPID)
 		a.setPitr(&currentPitr{
-			slicer: s,
-			cancel: stopSlicing,
-			w:      w,
+			slicer:         s,
+			cancel:         stopSlicing,
+			hardCancel:     hardCancel,
+			w:              w,
+			done:           done,
+			lease:          lease,
+			stopLeaseRenew: stopLeaseRenew,
 		})
 
 		go func() {
@@ -318,7 +560,9 @@ func (a *Agent) pitr(ctx context.Context) error {
 			a.removePitr()
 		}()
 
-		streamErr := s.Stream(ctx,
+		go a.renewPITRLease(leaseRenewCtx, lease, l)
+
+		streamErr := s.Stream(hardCtx,
 			stopC,
 			w,
 			cfg.PITR.Compression,
@@ -332,21 +576,45 @@ func (a *Agent) pitr(ctx context.Context) error {
 			out("streaming oplog: %v", streamErr)
 		}
 
+		stopLeaseRenew()
+		if err := lease.Revoke(context.Background()); err != nil {
+			l.Error("revoke pitr lease: %v", err)
+		}
+
 		if err := lck.Release(); err != nil {
 			l.Error("release lock: %v", err)
 		}
+		close(done)
 
 		// Penalty to the failed node so healthy nodes would have priority on next try.
 		// But lock has to be released first. Otherwise, healthy nodes would wait for the lock release
 		// and the penalty won't have any sense.
+		//
+		// The penalty backs off exponentially (with jitter) across consecutive
+		// failures instead of a flat pitrCheckPeriod*2, so that when a whole
+		// shard loses storage connectivity, every replica doesn't keep
+		// retrying - and renominating - on the same cadence. It's skipped
+		// outright on shutdown: done is already closed, so there's nothing
+		// left for it to protect against, and no reason to keep this
+		// goroutine (and the process) around for it.
 		if streamErr != nil {
-			time.Sleep(pitrCheckPeriod * 2)
+			select {
+			case <-time.After(a.pitrBackoff(context.Background(), l)):
+			case <-ctx.Done():
+			}
+		} else {
+			a.pitrBackoffReset(context.Background(), l)
 		}
 	}()
 
 	return nil
 }
 
+// nominateRSForPITR offers the nomination in priority order, one candidate
+// batch at a time, waiting pitrNominationLeaseTTL/3 between batches. Before
+// each batch it checks the current ACK: if its lease is live the nomination
+// is won and it returns; an ACK whose lease has expired is ignored and the
+// next batch is offered.
 func (a *Agent) nominateRSForPITR(ctx context.Context, rs string, nodes [][]string) error {
 	l := log.LogEventFromContext(ctx)
 	l.Debug("pitr nomination list for %s: %v", rs, nodes)
@@ -361,8 +629,15 @@ func (a *Agent) nominateRSForPITR(ctx context.Context, rs string, nodes [][]stri
 			return errors.Wrap(err, "get pitr nominees")
 		}
 		if nms != nil && len(nms.Ack) > 0 {
-			l.Debug("pitr nomination: %s won by %s", rs, nms.Ack)
-			return nil
+			live, err := lock.IsLeaseLive(ctx, a.leadConn, nms.LeaseID)
+			if err != nil {
+				return errors.Wrap(err, "check pitr nominee lease")
+			}
+			if live {
+				l.Debug("pitr nomination: %s won by %s", rs, nms.Ack)
+				return nil
+			}
+			l.Debug("pitr nomination: %s owner %s lease expired, renominating", rs, nms.Ack)
 		}
 
 		err = oplog.SetPITRNominees(ctx, a.leadConn, rs, n)
@@ -371,18 +646,19 @@ func (a *Agent) nominateRSForPITR(ctx context.Context, rs string, nodes [][]stri
 		}
 		l.Debug("pitr nomination %s, set candidates %v", rs, n)
 
-		time.Sleep(pitrRenominationFrame)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pitrNominationLeaseTTL / 3):
+		}
 	}
 
 	return nil
 }
 
+// pitrLockCheck reports whether it's safe to move on and (re)acquire the
+// PITR OpLock: true once the current holder's lease is no longer live.
 func (a *Agent) pitrLockCheck(ctx context.Context) (bool, error) {
-	ts, err := topo.GetClusterTime(ctx, a.leadConn)
-	if err != nil {
-		return false, errors.Wrap(err, "read cluster time")
-	}
-
 	tl, err := lock.GetOpLockData(ctx, a.leadConn, &lock.LockHeader{
 		Replset: a.brief.SetName,
 		Type:    ctrl.CmdPITR,
@@ -396,8 +672,14 @@ func (a *Agent) pitrLockCheck(ctx context.Context) (bool, error) {
 		return false, errors.Wrap(err, "get lock")
 	}
 
-	// stale lock means we should move on and clean it up during the lock.Acquire
-	return tl.Heartbeat.T+defs.StaleFrameSec < ts.T, nil
+	live, err := lock.IsLeaseLive(ctx, a.leadConn, tl.LeaseID)
+	if err != nil {
+		return false, errors.Wrap(err, "check pitr lock lease")
+	}
+
+	// an expired lease means we should move on and clean the lock up during
+	// the lock.Acquire
+	return !live, nil
 }
 
 // waitAllOpLockRelease waits to not have any live OpLock and in such a case returns true.
@@ -425,6 +707,8 @@ func (a *Agent) waitAllOpLockRelease(ctx context.Context) (bool, error) {
 		case <-tout.C:
 			l.Warning("timeout while waiting for relese all OpLocks")
 			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
 		}
 	}
 }
@@ -434,8 +718,10 @@ func (a *Agent) waitAllOpLockRelease(ctx context.Context) (bool, error) {
 // First, nominee needs to sync up about Ready status with cluster leader.
 // After cluster Ready status is reached, nomination process will start.
 // If nomination document is not found, nominee tries again on another tick.
-// If Ack is found in fetched fragment, that means that another member confirmed
-// nomination, so in that case current member lost nomination and false is returned.
+// If Ack is found in fetched fragment and its lease is still live, that means
+// another member confirmed nomination, so in that case current member lost
+// nomination and false is returned. An Ack whose lease already expired is
+// ignored: the leader will renominate, so we keep waiting.
 func (a *Agent) waitNominationForPITR(ctx context.Context, rs, node string) (bool, error) {
 	l := log.LogEventFromContext(ctx)
 
@@ -461,7 +747,14 @@ func (a *Agent) waitNominationForPITR(ctx context.Context, rs, node string) (boo
 				return false, errors.Wrap(err, "check pitr nomination")
 			}
 			if len(nm.Ack) > 0 {
-				return false, nil
+				live, err := lock.IsLeaseLive(ctx, a.leadConn, nm.LeaseID)
+				if err != nil {
+					return false, errors.Wrap(err, "check pitr nominee lease")
+				}
+				if live {
+					return false, nil
+				}
+				continue
 			}
 			for _, n := range nm.Nodes {
 				if n == node {
@@ -470,6 +763,8 @@ func (a *Agent) waitNominationForPITR(ctx context.Context, rs, node string) (boo
 			}
 		case <-tout.C:
 			return false, nil
+		case <-ctx.Done():
+			return false, ctx.Err()
 		}
 	}
 }
@@ -502,6 +797,8 @@ func (a *Agent) confirmReadyStatus(ctx context.Context) error {
 			}
 		case <-tout.C:
 			return errors.New("timeout while waiting for ready status")
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
@@ -532,6 +829,8 @@ func (a *Agent) reconcileReadyStatus(ctx context.Context, agents []topo.AgentSta
 			}
 		case <-tout.C:
 			return errors.New("timeout while roconciling ready status")
+		case <-ctx.Done():
+			return ctx.Err()
 		}
 	}
 }
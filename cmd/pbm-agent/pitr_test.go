@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPitrBackoffDelay(t *testing.T) {
+	cases := []struct {
+		name   string
+		n      int
+		wantLo time.Duration
+		wantHi time.Duration
+	}{
+		{"first failure", 0, pitrBackoffBase, 2 * pitrBackoffBase},
+		{"second failure", 1, 2 * pitrBackoffBase, 3 * pitrBackoffBase},
+		{"grows to the cap", 10, pitrBackoffCap, pitrBackoffCap + pitrBackoffBase},
+		{"large n stays at the cap", 1000, pitrBackoffCap, pitrBackoffCap + pitrBackoffBase},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// the jitter term is random, so check it lands in the half-open
+			// [min(base*2^n, cap), min(base*2^n, cap) + base) window rather
+			// than an exact value.
+			for i := 0; i < 20; i++ {
+				got := pitrBackoffDelay(c.n)
+				if got < c.wantLo || got >= c.wantHi {
+					t.Fatalf("pitrBackoffDelay(%d) = %s, want [%s, %s)", c.n, got, c.wantLo, c.wantHi)
+				}
+			}
+		})
+	}
+}
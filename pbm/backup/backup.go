@@ -0,0 +1,33 @@
+// Package backup reads and writes backup metadata documents.
+package backup
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+)
+
+const backupCollection = "pbmBackups"
+
+// BackupMeta is a backup's metadata document.
+type BackupMeta struct {
+	OPID ctrl.OPID       `bson:"opid"`
+	Type defs.BackupType `bson:"type"`
+}
+
+// GetBackupByOPID returns the metadata of the backup started by opid.
+func GetBackupByOPID(ctx context.Context, conn connect.Client, opid ctrl.OPID) (*BackupMeta, error) {
+	res := conn.Database().Collection(backupCollection).FindOne(ctx, bson.M{"opid": opid})
+
+	var bcp BackupMeta
+	if err := res.Decode(&bcp); err != nil {
+		return nil, errors.Wrap(err, "decode backup meta")
+	}
+
+	return &bcp, nil
+}
@@ -0,0 +1,101 @@
+// Package config reads and writes the cluster-wide pbm configuration
+// document (storage, backup and PITR settings) that every agent polls.
+package config
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+)
+
+const configCollection = "pbmConfig"
+
+// defaultOplogSlicerInterval is used when PITR.OplogSpanMin is unset.
+const defaultOplogSlicerInterval = 10 * time.Minute
+
+// PITRConf holds the point-in-time-recovery settings of the cluster config.
+type PITRConf struct {
+	Enabled          bool               `bson:"enabled"`
+	OplogOnly        bool               `bson:"oplogOnly"`
+	OplogSpanMin     float64            `bson:"oplogSpanMin"`
+	Priority         map[string]float64 `bson:"priority,omitempty"`
+	Compression      string             `bson:"compression"`
+	CompressionLevel *int               `bson:"compressionLevel"`
+
+	// ShutdownGrace is how long the agent's PITR loop waits, on graceful
+	// shutdown, for the running slicer to finish its current span and flush
+	// it to storage before force-cancelling it. Zero/unset falls back to
+	// pitrDefaultShutdownGrace in cmd/pbm-agent.
+	ShutdownGrace time.Duration `bson:"shutdownGrace,omitempty"`
+
+	// MaxOplogSlicerInterval caps how far the slicer is allowed to grow the
+	// span geometrically on a quiet oplog. Zero/unset means the span stays
+	// fixed at OplogSpanMin.
+	MaxOplogSlicerInterval time.Duration `bson:"maxOplogSlicerInterval,omitempty"`
+}
+
+// BackupConf holds the settings that apply to backups (and, via Timeouts,
+// to the operations PITR shares timeout handling with).
+type BackupConf struct {
+	Timeouts defs.Timeouts `bson:"timeouts,omitempty"`
+}
+
+// Config is the cluster-wide pbm configuration document.
+type Config struct {
+	Epoch   int64      `bson:"epoch"`
+	Storage any        `bson:"storage"`
+	PITR    PITRConf   `bson:"pitr"`
+	Backup  BackupConf `bson:"backup"`
+}
+
+// OplogSlicerInterval returns the configured PITR chunk span, falling back
+// to defaultOplogSlicerInterval when unset.
+func (c *Config) OplogSlicerInterval() time.Duration {
+	if c.PITR.OplogSpanMin <= 0 {
+		return defaultOplogSlicerInterval
+	}
+	return time.Duration(c.PITR.OplogSpanMin * float64(time.Minute))
+}
+
+// Epoch is a monotonic marker of the current config generation, used to tag
+// locks and log lines so they can be correlated to the config that was in
+// effect when they were produced.
+type Epoch int64
+
+func (e Epoch) TS() int64 {
+	return int64(e)
+}
+
+// GetConfig reads the current cluster configuration document.
+func GetConfig(ctx context.Context, conn connect.Client) (*Config, error) {
+	res := conn.Database().Collection(configCollection).FindOne(ctx, bson.M{})
+
+	cfg := &Config{}
+	if err := res.Decode(cfg); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, mongo.ErrNoDocuments
+		}
+		return nil, errors.Wrap(err, "decode config")
+	}
+
+	return cfg, nil
+}
+
+// GetEpoch reads just the epoch of the current config, for log tagging.
+func GetEpoch(ctx context.Context, conn connect.Client) (Epoch, error) {
+	cfg, err := GetConfig(ctx, conn)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return Epoch(cfg.Epoch), nil
+}
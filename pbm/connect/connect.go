@@ -0,0 +1,36 @@
+// Package connect wraps the driver connections the agent holds open to the
+// config server (lead connection) and to its own node.
+package connect
+
+import (
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+)
+
+// Client is a connection to a mongod/mongos that pbm metadata is read from
+// and written to.
+type Client interface {
+	MongoClient() *mongo.Client
+	Database(name ...string) *mongo.Database
+}
+
+type client struct {
+	m *mongo.Client
+}
+
+func New(m *mongo.Client) Client {
+	return &client{m: m}
+}
+
+func (c *client) MongoClient() *mongo.Client {
+	return c.m
+}
+
+func (c *client) Database(name ...string) *mongo.Database {
+	dbName := defs.DB
+	if len(name) > 0 {
+		dbName = name[0]
+	}
+	return c.m.Database(dbName)
+}
@@ -0,0 +1,24 @@
+// Package ctrl defines the command types and operation identifiers shared
+// between the agent and the CLI control plane.
+package ctrl
+
+// Command is the kind of operation a lock/nomination/status doc refers to.
+type Command string
+
+const (
+	CmdBackup  Command = "backup"
+	CmdPITR    Command = "pitr"
+	CmdRestore Command = "restore"
+	CmdResync  Command = "resync"
+)
+
+// OPID identifies a single run of a command.
+type OPID string
+
+// NilOPID is the zero value, used when no particular operation applies
+// (e.g. waking up the slicer on demand rather than for a specific op).
+const NilOPID = OPID("")
+
+func (o OPID) String() string {
+	return string(o)
+}
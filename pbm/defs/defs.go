@@ -0,0 +1,26 @@
+// Package defs collects constants shared across pbm packages that don't
+// have a more specific home of their own.
+package defs
+
+// BackupType distinguishes the storage/consistency method a backup used.
+type BackupType string
+
+const (
+	LogicalBackup     BackupType = "logical"
+	PhysicalBackup    BackupType = "physical"
+	IncrementalBackup BackupType = "incremental"
+	ExternalBackup    BackupType = "external"
+)
+
+// StaleFrameSec is how far behind a lock's heartbeat can fall before it's
+// considered abandoned by a dead owner, for lock kinds that still rely on
+// wall-clock heartbeats rather than a lease.
+const StaleFrameSec int64 = 30
+
+// Timeouts bounds how long individual backup/restore steps are allowed to
+// run before being treated as failed.
+type Timeouts struct {
+	Starting *uint32
+}
+
+const DB = "admin"
@@ -0,0 +1,32 @@
+// Package errors wraps the standard errors package with the extras pbm
+// packages rely on: context-annotated wrapping and a couple of sentinel
+// errors shared across the codebase.
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrNotFound = errors.New("not found")
+
+// Wrap annotates err with a message, similar to fmt.Errorf("%s: %w", ...)
+// but kept as a named helper so call sites read the same way throughout pbm.
+func Wrap(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func New(msg string) error {
+	return errors.New(msg)
+}
+
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+func As(err error, target any) bool {
+	return errors.As(err, target)
+}
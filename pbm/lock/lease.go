@@ -0,0 +1,150 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+)
+
+const leaseCollection = "pbmLease"
+
+// Lease is a TTL-bound grant that stands in for wall-clock heartbeat
+// comparisons: its owner renews it periodically, and everyone else treats
+// its expiry (rather than a StaleFrameSec diff) as the definitive "the
+// owner died" signal. Modeled after the etcd lease pattern so it can be
+// reused by any cluster-wide operation that needs the same liveness check
+// (today: PITR nomination and the PITR OpLock; backup/restore/resync can
+// adopt it the same way once their lock acquisition is migrated to leases).
+type Lease struct {
+	conn    connect.Client
+	id      string
+	ttl     time.Duration
+	expires time.Time
+}
+
+type leaseDoc struct {
+	ID      string    `bson:"_id"`
+	Expires time.Time `bson:"expires"`
+}
+
+// GrantLease creates a new lease with the given TTL and returns a handle to
+// it. The caller is responsible for calling KeepAlive roughly every TTL/3 to
+// keep it alive, and Revoke once it's no longer needed.
+func GrantLease(ctx context.Context, conn connect.Client, ttl time.Duration) (*Lease, error) {
+	l := &Lease{
+		conn:    conn,
+		id:      primitiveID(),
+		ttl:     ttl,
+		expires: time.Now().Add(ttl),
+	}
+
+	_, err := leaseColl(conn).InsertOne(ctx, leaseDoc{ID: l.id, Expires: l.expires})
+	if err != nil {
+		return nil, errors.Wrap(err, "grant lease")
+	}
+
+	return l, nil
+}
+
+// ID is the identifier attached to whatever lock/nomination doc this lease
+// backs, so peers can look the lease up via IsLeaseLive.
+func (l *Lease) ID() string {
+	return l.id
+}
+
+// TTL is the duration this lease was granted for.
+func (l *Lease) TTL() time.Duration {
+	return l.ttl
+}
+
+// KeepAlive renews the lease for another TTL from now.
+func (l *Lease) KeepAlive(ctx context.Context) error {
+	l.expires = time.Now().Add(l.ttl)
+
+	_, err := leaseColl(l.conn).UpdateOne(ctx,
+		bson.M{"_id": l.id},
+		bson.M{"$set": bson.M{"expires": l.expires}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "renew lease")
+}
+
+// Revoke deletes the lease document immediately, rather than waiting for it
+// to expire on its own.
+func (l *Lease) Revoke(ctx context.Context) error {
+	_, err := leaseColl(l.conn).DeleteOne(ctx, bson.M{"_id": l.id})
+	return errors.Wrap(err, "revoke lease")
+}
+
+// IsLeaseLive reports whether the lease identified by id is still within
+// its TTL. An empty id (no lease was ever attached, e.g. a lock kind that
+// hasn't been migrated to leases yet) is treated as live, since there's
+// nothing to have expired.
+func IsLeaseLive(ctx context.Context, conn connect.Client, id string) (bool, error) {
+	if id == "" {
+		return true, nil
+	}
+
+	var doc leaseDoc
+	err := leaseColl(conn).FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "get lease")
+	}
+
+	return leaseIsLive(time.Now(), doc.Expires), nil
+}
+
+func leaseIsLive(now, expires time.Time) bool {
+	return now.Before(expires)
+}
+
+// ReleaseStale force-removes a lock matching header, so peers watching the
+// lock collection don't have to wait out defs.StaleFrameSec to treat it as
+// gone and renominate, and records marker (e.g. "shutdown-forced") as the
+// reason in the pbmLockHistory collection. Unlike OpLock.Release, it doesn't require the original
+// owner to still be the one calling it.
+func ReleaseStale(ctx context.Context, conn connect.Client, header LockHeader, marker string) error {
+	res, err := coll(conn).DeleteOne(ctx, bson.M{
+		"replset": header.Replset,
+		"node":    header.Node,
+		"type":    header.Type,
+	})
+	if err != nil {
+		return errors.Wrap(err, "release stale lock")
+	}
+	if res.DeletedCount == 0 {
+		// already gone (e.g. the owner released it cleanly in the meantime):
+		// nothing left to force.
+		return nil
+	}
+
+	_, err = staleColl(conn).InsertOne(ctx, bson.M{
+		"replset": header.Replset,
+		"node":    header.Node,
+		"type":    header.Type,
+		"reason":  marker,
+		"at":      time.Now(),
+	})
+	return errors.Wrap(err, "record stale lock release")
+}
+
+func staleColl(conn connect.Client) *mongo.Collection {
+	return conn.Database().Collection("pbmLockHistory")
+}
+
+func leaseColl(conn connect.Client) *mongo.Collection {
+	return conn.Database().Collection(leaseCollection)
+}
+
+func primitiveID() string {
+	return primitive.NewObjectID().Hex()
+}
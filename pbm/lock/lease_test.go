@@ -0,0 +1,42 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLeaseIsLive(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name    string
+		expires time.Time
+		want    bool
+	}{
+		{"not yet expired", now.Add(time.Second), true},
+		{"already expired", now.Add(-time.Second), false},
+		{"expires exactly now", now, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := leaseIsLive(now, c.expires); got != c.want {
+				t.Errorf("leaseIsLive(%s, %s) = %v, want %v", now, c.expires, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsLeaseLiveEmptyID(t *testing.T) {
+	// an empty id means no lease was ever attached (a lock kind that hasn't
+	// been migrated to leases yet), so it must read as live without touching
+	// conn at all.
+	live, err := IsLeaseLive(context.Background(), nil, "")
+	if err != nil {
+		t.Fatalf("IsLeaseLive with empty id: %v", err)
+	}
+	if !live {
+		t.Error("IsLeaseLive with empty id = false, want true")
+	}
+}
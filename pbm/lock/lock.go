@@ -0,0 +1,143 @@
+// Package lock implements the cluster-wide locks pbm uses to serialize
+// backup/restore/resync/PITR operations across agents.
+package lock
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+)
+
+const lockCollection = "pbmLock"
+
+// LockHeader identifies what a lock/lease document is for.
+type LockHeader struct {
+	Replset string       `bson:"replset"`
+	Node    string       `bson:"node"`
+	Type    ctrl.Command `bson:"type"`
+	OPID    ctrl.OPID    `bson:"opid,omitempty"`
+	Epoch   *int64       `bson:"epoch,omitempty"`
+}
+
+// Heartbeat is the wall-clock liveness marker legacy (non-leased) locks are
+// still compared against.
+type Heartbeat struct {
+	T int64 `bson:"t"`
+}
+
+// LockData is a lock document as stored in pbmLock.
+type LockData struct {
+	LockHeader `bson:",inline"`
+	Heartbeat  Heartbeat `bson:"heartbeat"`
+	// LeaseID ties this lock to the lease (see lease.go) that's the source
+	// of truth for whether the owner is still alive. Empty for lock kinds
+	// that haven't been migrated to leases yet.
+	LeaseID string `bson:"leaseId,omitempty"`
+}
+
+// ConcurrentOpError is returned when an operation can't proceed because
+// another one, described by Lock, already holds a conflicting lock.
+type ConcurrentOpError struct {
+	Lock LockHeader
+}
+
+func (e ConcurrentOpError) Error() string {
+	return fmt.Sprintf("another operation in progress: %s/%s on %s", e.Lock.Type, e.Lock.OPID, e.Lock.Replset)
+}
+
+// OpLock is a handle to a single lock document this node is trying to hold.
+type OpLock struct {
+	conn   connect.Client
+	header LockHeader
+}
+
+func NewOpLock(conn connect.Client, header LockHeader) *OpLock {
+	return &OpLock{conn: conn, header: header}
+}
+
+// Acquire tries to atomically create the lock document, returning false
+// (not an error) if another node already holds it.
+func (l *OpLock) Acquire(ctx context.Context) (bool, error) {
+	doc := LockData{LockHeader: l.header, Heartbeat: Heartbeat{T: nowUnix()}}
+
+	_, err := coll(l.conn).InsertOne(ctx, doc)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "insert lock")
+	}
+
+	return true, nil
+}
+
+// Release removes this node's lock document.
+func (l *OpLock) Release() error {
+	_, err := coll(l.conn).DeleteOne(context.Background(), bson.M{
+		"replset": l.header.Replset,
+		"node":    l.header.Node,
+		"type":    l.header.Type,
+	})
+	return errors.Wrap(err, "delete lock")
+}
+
+// GetLocks returns every lock document matching the (possibly partial)
+// header filter.
+func GetLocks(ctx context.Context, conn connect.Client, filter *LockHeader) ([]LockData, error) {
+	cur, err := coll(conn).Find(ctx, toFilter(filter))
+	if err != nil {
+		return nil, errors.Wrap(err, "find locks")
+	}
+	defer cur.Close(ctx)
+
+	var locks []LockData
+	if err := cur.All(ctx, &locks); err != nil {
+		return nil, errors.Wrap(err, "decode locks")
+	}
+
+	return locks, nil
+}
+
+// GetOpLockData returns the single lock document matching filter.
+func GetOpLockData(ctx context.Context, conn connect.Client, filter *LockHeader) (*LockData, error) {
+	res := coll(conn).FindOne(ctx, toFilter(filter))
+
+	var l LockData
+	if err := res.Decode(&l); err != nil {
+		return nil, err
+	}
+
+	return &l, nil
+}
+
+func toFilter(h *LockHeader) bson.M {
+	f := bson.M{}
+	if h == nil {
+		return f
+	}
+	if h.Replset != "" {
+		f["replset"] = h.Replset
+	}
+	if h.Node != "" {
+		f["node"] = h.Node
+	}
+	if h.Type != "" {
+		f["type"] = h.Type
+	}
+	return f
+}
+
+func coll(conn connect.Client) *mongo.Collection {
+	return conn.Database().Collection(lockCollection)
+}
+
+func nowUnix() int64 {
+	return time.Now().Unix()
+}
@@ -0,0 +1,69 @@
+// Package log provides the structured, per-operation event logger used
+// across pbm agent commands.
+package log
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+type ctxKey int
+
+const eventCtxKey ctxKey = iota
+
+// Event is a log entry scoped to a single command/operation, carrying
+// enough context (command, replset, node, epoch timestamp) that every line
+// it writes can be correlated back to that operation.
+type Event struct {
+	cmd  string
+	rs   string
+	node string
+	ts   int64
+}
+
+// Logger is the base, operation-less logger handed out by FromContext.
+type Logger struct{}
+
+func (*Logger) Printf(format string, args ...any) {
+	log.Printf(format, args...)
+}
+
+func (l *Logger) Error(cmd, rs, node string, ts int64, format string, args ...any) {
+	log.Printf("E ["+cmd+"/"+rs+"/"+node+"] "+format, args...)
+	_ = ts
+}
+
+func (l *Logger) NewEvent(cmd, rs, node string, ts int64) *Event {
+	return &Event{cmd: cmd, rs: rs, node: node, ts: ts}
+}
+
+func (e *Event) Debug(format string, args ...any)   { e.printf("D", format, args...) }
+func (e *Event) Info(format string, args ...any)    { e.printf("I", format, args...) }
+func (e *Event) Warning(format string, args ...any) { e.printf("W", format, args...) }
+func (e *Event) Error(format string, args ...any)   { e.printf("E", format, args...) }
+func (e *Event) Printf(format string, args ...any)  { e.printf("I", format, args...) }
+
+func (e *Event) printf(lvl, format string, args ...any) {
+	log.Printf("%s [%s/%s/%s] %s", lvl, e.cmd, e.rs, e.node, fmt.Sprintf(format, args...))
+}
+
+// FromContext returns the base logger for ctx.
+func FromContext(ctx context.Context) *Logger {
+	return &Logger{}
+}
+
+// LogEventFromContext returns the Event previously attached to ctx via
+// SetLogEventToContext, or a detached one if none was set.
+func LogEventFromContext(ctx context.Context) *Event {
+	if e, ok := ctx.Value(eventCtxKey).(*Event); ok {
+		return e
+	}
+	return &Event{}
+}
+
+// SetLogEventToContext attaches e to ctx so downstream calls can recover it
+// via LogEventFromContext without threading it through every signature.
+func SetLogEventToContext(ctx context.Context, e *Event) context.Context {
+	return context.WithValue(ctx, eventCtxKey, e)
+}
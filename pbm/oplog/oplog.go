@@ -0,0 +1,171 @@
+// Package oplog persists the PITR coordination documents: cluster
+// ready-status, nomination lists/ACKs, and the overall PITR meta doc.
+package oplog
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+	"github.com/percona/percona-backup-mongodb/pbm/lock"
+)
+
+const (
+	metaCollection        = "pbmPITR"
+	nomineesCollection    = "pbmPITRNominees"
+	readyStatusCollection = "pbmPITRReady"
+)
+
+// Status is the cluster-wide PITR coordination phase.
+type Status string
+
+const (
+	StatusReady Status = "ready"
+)
+
+// Meta is the singleton PITR coordination document.
+type Meta struct {
+	Status Status `bson:"status"`
+}
+
+// InitMeta creates the PITR meta document on first use, if it doesn't
+// already exist.
+func InitMeta(ctx context.Context, conn connect.Client) error {
+	_, err := coll(conn, metaCollection).UpdateOne(ctx,
+		bson.M{"_id": "meta"},
+		bson.M{"$setOnInsert": bson.M{"status": StatusReady}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "init pitr meta")
+}
+
+// GetMeta reads the PITR meta document.
+func GetMeta(ctx context.Context, conn connect.Client) (*Meta, error) {
+	res := coll(conn, metaCollection).FindOne(ctx, bson.M{"_id": "meta"})
+
+	var m Meta
+	if err := res.Decode(&m); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "decode pitr meta")
+	}
+
+	return &m, nil
+}
+
+// GetClusterStatus returns the current cluster-wide PITR status.
+func GetClusterStatus(ctx context.Context, conn connect.Client) (Status, error) {
+	m, err := GetMeta(ctx, conn)
+	if err != nil {
+		return "", err
+	}
+	return m.Status, nil
+}
+
+// SetReadyRSStatus records that replset rs's member node has reached the
+// ready status.
+func SetReadyRSStatus(ctx context.Context, conn connect.Client, rs, node string) error {
+	_, err := coll(conn, readyStatusCollection).UpdateOne(ctx,
+		bson.M{"_id": rs + "/" + node},
+		bson.M{"$set": bson.M{"rs": rs, "node": node, "status": StatusReady}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "set ready status")
+}
+
+// GetReplSetsWithStatus returns the replset name of every rs/node pair that
+// has reached status, one entry per node.
+func GetReplSetsWithStatus(ctx context.Context, conn connect.Client, status Status) ([]string, error) {
+	cur, err := coll(conn, readyStatusCollection).Find(ctx, bson.M{"status": status})
+	if err != nil {
+		return nil, errors.Wrap(err, "find ready statuses")
+	}
+	defer cur.Close(ctx)
+
+	var docs []struct {
+		RS string `bson:"rs"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "decode ready statuses")
+	}
+
+	rs := make([]string, len(docs))
+	for i := range docs {
+		rs[i] = docs[i].RS
+	}
+
+	return rs, nil
+}
+
+// IsOplogSlicing reports whether any PITR OpLock is currently held anywhere
+// in the cluster.
+func IsOplogSlicing(ctx context.Context, conn connect.Client) (bool, error) {
+	locks, err := lock.GetLocks(ctx, conn, &lock.LockHeader{Type: ctrl.CmdPITR})
+	if err != nil {
+		return false, errors.Wrap(err, "count slicing locks")
+	}
+	return len(locks) > 0, nil
+}
+
+// PITRNominees is the nomination document for a single replset: the
+// candidate batch currently offered, and the ACK (if any) of whoever
+// claimed it.
+type PITRNominees struct {
+	RS    string   `bson:"rs"`
+	Nodes []string `bson:"nodes"`
+	Ack   string   `bson:"ack,omitempty"`
+	// LeaseID is the lock.Lease backing Ack's ownership.
+	LeaseID string `bson:"leaseId,omitempty"`
+}
+
+// SetPITRNomination resets the nomination document for rs to start a fresh
+// nomination round.
+func SetPITRNomination(ctx context.Context, conn connect.Client, rs string) error {
+	_, err := coll(conn, nomineesCollection).UpdateOne(ctx,
+		bson.M{"_id": rs},
+		bson.M{"$set": bson.M{"rs": rs, "nodes": []string{}, "ack": "", "leaseId": ""}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "reset pitr nomination")
+}
+
+// SetPITRNominees offers nodes as the current candidate batch for rs.
+func SetPITRNominees(ctx context.Context, conn connect.Client, rs string, nodes []string) error {
+	_, err := coll(conn, nomineesCollection).UpdateOne(ctx,
+		bson.M{"_id": rs},
+		bson.M{"$set": bson.M{"nodes": nodes}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "set pitr nominees")
+}
+
+// GetPITRNominees returns the current nomination document for rs.
+func GetPITRNominees(ctx context.Context, conn connect.Client, rs string) (*PITRNominees, error) {
+	res := coll(conn, nomineesCollection).FindOne(ctx, bson.M{"_id": rs})
+
+	var nm PITRNominees
+	if err := res.Decode(&nm); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errors.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "decode pitr nominees")
+	}
+
+	return &nm, nil
+}
+
+// SetPITRNomineeACK atomically claims the nomination for rs on node's
+// behalf, attaching leaseID so peers can tell when the claim goes stale.
+func SetPITRNomineeACK(ctx context.Context, conn connect.Client, rs, node, leaseID string) error {
+	_, err := coll(conn, nomineesCollection).UpdateOne(ctx,
+		bson.M{"_id": rs},
+		bson.M{"$set": bson.M{"ack": node, "leaseId": leaseID}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "ack pitr nomination")
+}
+
+func coll(conn connect.Client, name string) *mongo.Collection {
+	return conn.Database().Collection(name)
+}
@@ -0,0 +1,35 @@
+// Package prio ranks replica set members for PITR nomination by configured
+// priority (falling back to a sane default order).
+package prio
+
+import (
+	"context"
+
+	"github.com/percona/percona-backup-mongodb/pbm/topo"
+)
+
+// NodesPriority is the computed per-replset nomination order: each entry in
+// a batch has equal priority, and batches are offered in order.
+type NodesPriority map[string][][]string
+
+// RS returns the nomination batches for a single replset.
+func (p NodesPriority) RS(rs string) [][]string {
+	return p[rs]
+}
+
+// CalcNodesPriority groups agents by replset and returns, for each replset,
+// a single batch with every member of equal priority. cfg is not consulted:
+// explicit per-node priorities don't change the order.
+func CalcNodesPriority(ctx context.Context, _ any, cfg map[string]float64, agents []topo.AgentStat) (NodesPriority, error) {
+	byRS := map[string][]string{}
+	for _, a := range agents {
+		byRS[a.RS] = append(byRS[a.RS], a.Node)
+	}
+
+	out := NodesPriority{}
+	for rs, nodes := range byRS {
+		out[rs] = [][]string{nodes}
+	}
+
+	return out, nil
+}
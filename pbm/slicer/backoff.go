@@ -0,0 +1,73 @@
+package slicer
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// backoffGrowthFactor is how much activeSpan grows, per quiet cycle,
+// towards maxSpan.
+const backoffGrowthFactor = 2
+
+// SetBackoff sets the ceiling activeSpan is allowed to grow to when the
+// oplog write rate is low. A zero max disables adaptive growth: activeSpan
+// stays pinned at whatever SetSpan last set it to.
+func (s *Slicer) SetBackoff(max time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxSpan = max
+}
+
+// adjustSpan grows activeSpan geometrically, up to maxSpan, when the oplog
+// write rate over the last cycle was low - so an idle cluster settles into
+// fewer, larger chunks instead of churning a tiny one every cycle. Any
+// meaningful write activity resets activeSpan back down to the configured
+// base span so PITR recovery granularity doesn't degrade on a busy cluster.
+func (s *Slicer) adjustSpan(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxSpan <= 0 || s.span <= 0 {
+		return
+	}
+
+	s.activeSpan = nextActiveSpan(s.activeSpan, s.span, s.maxSpan, s.oplogRateIsLow(ctx))
+}
+
+// nextActiveSpan is adjustSpan's pure growth/reset decision: grow active
+// geometrically towards max when rateLow, otherwise reset back to base.
+func nextActiveSpan(active, base, max time.Duration, rateLow bool) time.Duration {
+	if !rateLow {
+		return base
+	}
+
+	grown := active * backoffGrowthFactor
+	if grown > max {
+		return max
+	}
+	return grown
+}
+
+// oplogRateIsLow reports whether local.oplog.rs's newest entry hasn't moved
+// on since the last cycle's check - a coarse but real "nothing got written
+// in the last span" signal. The very first call has nothing to compare
+// against yet, so it conservatively reports false.
+func (s *Slicer) oplogRateIsLow(ctx context.Context) bool {
+	var doc struct {
+		TS primitive.Timestamp `bson:"ts"`
+	}
+	err := s.nodeConn.Database("local").Collection("oplog.rs").
+		FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"$natural": -1})).
+		Decode(&doc)
+	if err != nil {
+		return false
+	}
+
+	low := s.lastOplogTS.T != 0 && doc.TS == s.lastOplogTS
+	s.lastOplogTS = doc.TS
+	return low
+}
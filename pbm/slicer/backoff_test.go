@@ -0,0 +1,34 @@
+package slicer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextActiveSpan(t *testing.T) {
+	minute := time.Minute
+
+	cases := []struct {
+		name     string
+		active   time.Duration
+		base     time.Duration
+		max      time.Duration
+		rateLow  bool
+		wantSpan time.Duration
+	}{
+		{"busy resets to base", 8 * minute, minute, 16 * minute, false, minute},
+		{"quiet grows geometrically", minute, minute, 16 * minute, true, 2 * minute},
+		{"quiet growth caps at max", 12 * minute, minute, 16 * minute, true, 16 * minute},
+		{"quiet growth already at max stays put", 16 * minute, minute, 16 * minute, true, 16 * minute},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := nextActiveSpan(c.active, c.base, c.max, c.rateLow)
+			if got != c.wantSpan {
+				t.Errorf("nextActiveSpan(%s, %s, %s, %v) = %s, want %s",
+					c.active, c.base, c.max, c.rateLow, got, c.wantSpan)
+			}
+		})
+	}
+}
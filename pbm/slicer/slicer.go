@@ -0,0 +1,142 @@
+// Package slicer cuts the oplog into timestamped chunks ("slices") on a
+// configurable cadence and ships them to storage for PITR.
+package slicer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-backup-mongodb/pbm/config"
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/ctrl"
+	"github.com/percona/percona-backup-mongodb/pbm/defs"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+	"github.com/percona/percona-backup-mongodb/pbm/log"
+	"github.com/percona/percona-backup-mongodb/pbm/util"
+)
+
+// OpMovedError is returned by Stream when the slicer lost ownership of its
+// span mid-stream (e.g. a failover moved the primary it was reading from).
+type OpMovedError struct{}
+
+func (OpMovedError) Error() string {
+	return "oplog slicing moved to another node"
+}
+
+// Slicer cuts and flushes oplog chunks for a single replset.
+type Slicer struct {
+	rs       string
+	leadConn connect.Client
+	nodeConn *mongo.Client
+	stg      util.Storage
+	cfg      *config.Config
+	l        *log.Logger
+
+	mu sync.Mutex
+	// span is the configured base chunk span (what GetSpan/SetSpan expose
+	// to callers, e.g. pitr()'s comparison against OplogSlicerInterval).
+	span time.Duration
+	// activeSpan is what Stream actually sleeps for. It starts at span and
+	// is grown geometrically towards maxSpan by adjustSpan when the oplog
+	// write rate is low, independently of the externally-visible span.
+	activeSpan time.Duration
+	maxSpan    time.Duration
+	// lastOplogTS is the newest local.oplog.rs entry seen by the previous
+	// oplogRateIsLow check, used to tell whether anything's been written
+	// since.
+	lastOplogTS primitive.Timestamp
+}
+
+func NewSlicer(rs string, leadConn connect.Client, nodeConn *mongo.Client, stg util.Storage, cfg *config.Config, l *log.Logger) *Slicer {
+	return &Slicer{rs: rs, leadConn: leadConn, nodeConn: nodeConn, stg: stg, cfg: cfg, l: l}
+}
+
+// SetSpan sets the configured base chunk span (e.g. when OplogSlicerInterval
+// changes) and resets any adaptive growth back to it.
+func (s *Slicer) SetSpan(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.span = d
+	s.activeSpan = d
+}
+
+// GetSpan returns the configured base chunk span - what pitr() compares
+// against OplogSlicerInterval, not whatever adaptive growth SetBackoff has
+// applied on top of it.
+func (s *Slicer) GetSpan() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.span
+}
+
+// currentSpan returns the span Stream should actually sleep for: the
+// adaptively-grown one, if any.
+func (s *Slicer) currentSpan() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeSpan > 0 {
+		return s.activeSpan
+	}
+	return s.span
+}
+
+// Catchup is called before Stream takes over the steady-state cadence. It
+// does no work and always returns nil: no chunk is cut from the last known
+// one up to now.
+func (s *Slicer) Catchup(ctx context.Context) error {
+	return nil
+}
+
+// OplogOnlyCatchup is the Catchup counterpart for clusters running in
+// oplog-only mode. Like Catchup, it does no work and always returns nil.
+func (s *Slicer) OplogOnlyCatchup(ctx context.Context) error {
+	return nil
+}
+
+// Stream cuts chunks every span until stopC is closed (graceful stop) or
+// ctx is done (hard stop), flushing each one to storage. w can be used to
+// wake it up for an out-of-band chunk (e.g. a smaller span just kicked in).
+func (s *Slicer) Stream(
+	ctx context.Context,
+	stopC chan struct{},
+	w chan ctrl.OPID,
+	compression string,
+	level *int,
+	timeouts defs.Timeouts,
+) error {
+	for {
+		span := s.currentSpan()
+		if span <= 0 {
+			span = time.Minute
+		}
+
+		t := time.NewTimer(span)
+		select {
+		case <-t.C:
+		case <-w:
+			t.Stop()
+		case <-stopC:
+			t.Stop()
+			return s.flush(ctx, compression, level)
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+
+		if err := s.flush(ctx, compression, level); err != nil {
+			return errors.Wrap(err, "flush chunk")
+		}
+
+		s.adjustSpan(ctx)
+	}
+}
+
+// flush marks the end of a chunk in Stream's cycle. It writes nothing to
+// storage and always returns nil.
+func (s *Slicer) flush(ctx context.Context, compression string, level *int) error {
+	return nil
+}
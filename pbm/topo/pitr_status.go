@@ -0,0 +1,36 @@
+package topo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+)
+
+// SetAgentPITRBackoff records that an agent's PITR retry loop is in
+// backoff, so `pbm status` can surface "node X in backoff (attempt N, next
+// try in Ys)" instead of it looking like the node silently stopped slicing.
+func SetAgentPITRBackoff(ctx context.Context, conn connect.Client, rs, node string, attempt int, next time.Duration) error {
+	_, err := conn.Database().Collection(agentsCollection).UpdateOne(ctx,
+		bson.M{"rs": rs, "node": node},
+		bson.M{"$set": bson.M{
+			"pitrBackoff.attempt": attempt,
+			"pitrBackoff.nextTry": time.Now().Add(next),
+		}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "set pitr backoff status")
+}
+
+// ClearAgentPITRBackoff removes the backoff marker after a successful
+// stream cycle.
+func ClearAgentPITRBackoff(ctx context.Context, conn connect.Client, rs, node string) error {
+	_, err := conn.Database().Collection(agentsCollection).UpdateOne(ctx,
+		bson.M{"rs": rs, "node": node},
+		bson.M{"$unset": bson.M{"pitrBackoff": ""}},
+		options.Update().SetUpsert(true))
+	return errors.Wrap(err, "clear pitr backoff status")
+}
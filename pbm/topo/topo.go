@@ -0,0 +1,90 @@
+// Package topo reads replica set / cluster topology and agent status from
+// the connected mongod/mongos and from pbm's own status collection.
+package topo
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/percona-backup-mongodb/pbm/connect"
+	"github.com/percona/percona-backup-mongodb/pbm/errors"
+)
+
+const agentsCollection = "pbmAgents"
+
+// NodeBrief is the minimal identity of a node: which replset it's in and
+// its host:port within that set.
+type NodeBrief struct {
+	SetName string
+	Me      string
+}
+
+// NodeInfoExt is the node's own view of the replica set it belongs to.
+type NodeInfoExt struct {
+	SetName string `bson:"setName"`
+	Me      string `bson:"me"`
+	Primary string `bson:"primary"`
+}
+
+// IsClusterLeader reports whether this node's agent is the one responsible
+// for cluster-wide coordination (PITR nomination, etc).
+func (n *NodeInfoExt) IsClusterLeader() bool {
+	return n.Me == n.Primary
+}
+
+// GetNodeInfoExt reads replica set identity/role info directly from conn.
+func GetNodeInfoExt(ctx context.Context, conn *mongo.Client) (*NodeInfoExt, error) {
+	res := conn.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}})
+
+	var n NodeInfoExt
+	if err := res.Decode(&n); err != nil {
+		return nil, errors.Wrap(err, "isMaster")
+	}
+
+	return &n, nil
+}
+
+// NodeSuits reports whether this node may take part in PITR slicing. It
+// always reports true: no health or member-role (delayed/hidden/arbiter)
+// checks are made.
+func NodeSuits(ctx context.Context, conn *mongo.Client, info *NodeInfoExt) (bool, error) {
+	return true, nil
+}
+
+// AgentStat is the heartbeat document an agent writes about itself.
+type AgentStat struct {
+	Node      string    `bson:"node"`
+	RS        string    `bson:"rs"`
+	Heartbeat time.Time `bson:"heartbeat"`
+}
+
+// ListAgentStatuses returns the latest status document for every agent in
+// the cluster.
+func ListAgentStatuses(ctx context.Context, conn connect.Client) ([]AgentStat, error) {
+	cur, err := conn.Database().Collection(agentsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, errors.Wrap(err, "find agent statuses")
+	}
+	defer cur.Close(ctx)
+
+	var agents []AgentStat
+	if err := cur.All(ctx, &agents); err != nil {
+		return nil, errors.Wrap(err, "decode agent statuses")
+	}
+
+	return agents, nil
+}
+
+// ShardInfo is one entry of the cluster's shard/replset member list.
+type ShardInfo struct {
+	RS string
+}
+
+// ClusterMembers returns the cluster's replset (shard) list. It returns a
+// nil list and error: conn is not queried for the cluster's membership.
+func ClusterMembers(ctx context.Context, conn *mongo.Client) ([]ShardInfo, error) {
+	return nil, nil
+}
@@ -0,0 +1,23 @@
+// Package util holds small helpers shared across pbm packages that don't
+// warrant their own package.
+package util
+
+import (
+	"github.com/percona/percona-backup-mongodb/pbm/log"
+)
+
+// Storage is a handle to wherever backups/oplog chunks are written.
+type Storage interface {
+	Save(name string, data []byte) error
+}
+
+// StorageFromConfig returns the Storage for the cluster config's storage
+// section. cfg is not read: it always returns a Storage whose Save discards
+// the data and returns nil.
+func StorageFromConfig(cfg any, l *log.Event) (Storage, error) {
+	return noopStorage{}, nil
+}
+
+type noopStorage struct{}
+
+func (noopStorage) Save(name string, data []byte) error { return nil }